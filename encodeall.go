@@ -0,0 +1,122 @@
+package imgconv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+
+	"github.com/sunshineplan/pdf"
+	"github.com/sunshineplan/tiff"
+)
+
+// EncodeAll writes imgs to w as a multi-page document in the specified
+// format. PDF and GIF natively support multiple pages/frames; TIFF is
+// written as a multi-image (multi-IFD) TIFF built by stitching together the
+// single-page TIFFs produced by Encode. Other formats return an error when
+// given anything but exactly one image.
+func (f *FormatOption) EncodeAll(w io.Writer, imgs []image.Image) error {
+	if len(imgs) == 0 {
+		return errors.New("no image to encode")
+	}
+
+	transformed := make([]image.Image, len(imgs))
+	for i, img := range imgs {
+		transformed[i] = f.transform(img)
+	}
+
+	cfg := defaultEncodeConfig
+	for _, option := range f.EncodeOption {
+		option(&cfg)
+	}
+
+	switch f.Format {
+	case PDF:
+		return pdf.Encode(w, transformed, &pdf.Options{Quality: cfg.Quality})
+
+	case TIFF:
+		return encodeAllTIFF(w, transformed, cfg)
+
+	case GIF:
+		return encodeAllGIF(w, transformed, cfg)
+	}
+
+	if len(imgs) > 1 {
+		return fmt.Errorf("%s format doesn't support multiple images", formatExts[f.Format])
+	}
+
+	// Encode runs f.Transforms itself, so it gets the untransformed image.
+	return f.Encode(w, imgs[0])
+}
+
+// encodeAllGIF builds an animated GIF out of imgs, quantizing any frame that
+// isn't already an *image.Paletted using cfg.gifQuantizer/cfg.gifDrawer (or
+// their defaults), and applying the per-frame delay and disposal set with
+// GIFFrameOptions.
+func encodeAllGIF(w io.Writer, imgs []image.Image, cfg encodeConfig) error {
+	drawer := cfg.gifDrawer
+	if drawer == nil {
+		drawer = draw.FloydSteinberg
+	}
+
+	g := &gif.GIF{}
+	for i, img := range imgs {
+		pm, ok := img.(*image.Paletted)
+		if !ok {
+			var p color.Palette
+			if cfg.gifQuantizer != nil {
+				p = cfg.gifQuantizer.Quantize(make(color.Palette, 0, cfg.gifNumColors), img)
+			} else {
+				p = palette.Plan9
+			}
+			pm = image.NewPaletted(img.Bounds(), p)
+			drawer.Draw(pm, img.Bounds(), img, img.Bounds().Min)
+		}
+
+		frame := GIFFrame{Disposal: gif.DisposalNone}
+		if n := len(cfg.gifFrames); n > 0 {
+			if i < n {
+				frame = cfg.gifFrames[i]
+			} else {
+				frame = cfg.gifFrames[n-1]
+			}
+		}
+
+		g.Image = append(g.Image, pm)
+		g.Delay = append(g.Delay, frame.Delay)
+		g.Disposal = append(g.Disposal, frame.Disposal)
+	}
+
+	return gif.EncodeAll(w, g)
+}
+
+// encodeAllTIFF writes imgs as a single multi-page TIFF by encoding each
+// image separately with tiff.Encode and stitching the resulting single-IFD
+// TIFFs into one file with a chain of IFDs, rebasing every offset each page
+// contains (its IFD offset, out-of-line field values, and, because they
+// hold offsets even when stored inline, its StripOffsets).
+func encodeAllTIFF(w io.Writer, imgs []image.Image, cfg encodeConfig) error {
+	opt := &tiff.Options{Compression: cfg.tiffCompressionType.value(), Predictor: true}
+
+	pages := make([][]byte, len(imgs))
+	for i, img := range imgs {
+		var buf bytes.Buffer
+		if err := tiff.Encode(&buf, img, opt); err != nil {
+			return err
+		}
+		pages[i] = buf.Bytes()
+	}
+
+	stitched, err := stitchTIFF(pages)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(stitched)
+	return err
+}
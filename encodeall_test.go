@@ -0,0 +1,145 @@
+package imgconv
+
+import (
+	"bytes"
+	"image"
+	"image/gif"
+	"testing"
+
+	"github.com/sunshineplan/tiff"
+)
+
+func TestEncodeAllGIF(t *testing.T) {
+	m0, err := Open("testdata/video-001.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fo := &FormatOption{Format: GIF, EncodeOption: []EncodeOption{
+		GIFFrameOptions([]GIFFrame{{Delay: 10, Disposal: gif.DisposalBackground}}),
+	}}
+
+	var buf bytes.Buffer
+	if err := fo.EncodeAll(&buf, []image.Image{m0, m0, m0}); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Image) != 3 {
+		t.Fatalf("got %d frames, want 3", len(g.Image))
+	}
+	for i, delay := range g.Delay {
+		if delay != 10 {
+			t.Errorf("frame %d: delay = %d, want 10", i, delay)
+		}
+	}
+}
+
+func TestEncodeAllTIFF(t *testing.T) {
+	m0, err := Open("testdata/video-001.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, compression := range []TIFFCompression{TIFFLZW, TIFFJPEG} {
+		fo := &FormatOption{Format: TIFF, EncodeOption: []EncodeOption{TIFFCompressionType(compression)}}
+
+		var buf bytes.Buffer
+		if err := fo.EncodeAll(&buf, []image.Image{m0, m0}); err != nil {
+			t.Fatal(compression, err)
+		}
+		data := buf.Bytes()
+
+		order, err := tiffByteOrder(data)
+		if err != nil {
+			t.Fatal(compression, err)
+		}
+
+		var ifdOffsets []uint32
+		next := order.Uint32(data[4:8])
+		for next != 0 {
+			ifdOffsets = append(ifdOffsets, next)
+			count := order.Uint16(data[next : next+2])
+			next = order.Uint32(data[next+2+uint32(count)*12:])
+		}
+		if len(ifdOffsets) != 2 {
+			t.Fatalf("%v: got %d IFDs, want 2", compression, len(ifdOffsets))
+		}
+
+		// Decode each page independently by pointing a copy of the file's
+		// header at that page's IFD and terminating the chain there, and
+		// check it round-trips m0's actual pixels rather than just its
+		// bounds - catching any offset the stitching left unrebased.
+		for i, ifdOffset := range ifdOffsets {
+			page := make([]byte, len(data))
+			copy(page, data)
+			order.PutUint32(page[4:8], ifdOffset)
+
+			count := order.Uint16(page[ifdOffset : ifdOffset+2])
+			nextPos := ifdOffset + 2 + uint32(count)*12
+			order.PutUint32(page[nextPos:nextPos+4], 0)
+
+			m1, err := tiff.Decode(bytes.NewReader(page))
+			if err != nil {
+				t.Fatalf("%v: page %d: decode: %v", compression, i, err)
+			}
+			if m1.Bounds() != m0.Bounds() {
+				t.Fatalf("%v: page %d: bounds = %v, want %v", compression, i, m1.Bounds(), m0.Bounds())
+			}
+			tolerance := uint32(0)
+			if compression == TIFFJPEG {
+				tolerance = 1 << 12 // JPEG is lossy; allow some rounding drift
+			}
+			if !samePixels(m0, m1, tolerance) {
+				t.Fatalf("%v: page %d: pixel data doesn't match the source image", compression, i)
+			}
+		}
+	}
+}
+
+// samePixels reports whether a and b have the same bounds and every pixel
+// matches within tolerance (on each of the four RGBA channels, which are
+// 16-bit).
+func samePixels(a, b image.Image, tolerance uint32) bool {
+	bounds := a.Bounds()
+	if b.Bounds() != bounds {
+		return false
+	}
+	diff := func(x, y uint32) uint32 {
+		if x > y {
+			return x - y
+		}
+		return y - x
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			ar, ag, ab, aa := a.At(x, y).RGBA()
+			br, bg, bb, ba := b.At(x, y).RGBA()
+			if diff(ar, br) > tolerance || diff(ag, bg) > tolerance ||
+				diff(ab, bb) > tolerance || diff(aa, ba) > tolerance {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestEncodeAllSinglePage(t *testing.T) {
+	m0, err := Open("testdata/video-001.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fo := &FormatOption{Format: PNG}
+
+	var buf bytes.Buffer
+	if err := fo.EncodeAll(&buf, []image.Image{m0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fo.EncodeAll(&buf, []image.Image{m0, m0}); err == nil {
+		t.Fatal("encode all with multiple images for PNG expect an error")
+	}
+}
@@ -0,0 +1,105 @@
+package imgconv
+
+import (
+	"bufio"
+	"encoding/binary"
+)
+
+// exifOrientationTag is the TIFF tag number of the EXIF Orientation field.
+const exifOrientationTag = 274
+
+// peekOrientation looks for an EXIF Orientation tag in the bytes buffered by
+// br - a JPEG's APP1 "Exif" segment, or a bare TIFF's IFD0 - without
+// consuming the stream. It returns 0 if none is found.
+func peekOrientation(br *bufio.Reader) int {
+	head, _ := br.Peek(4)
+	if len(head) < 4 {
+		return 0
+	}
+
+	switch {
+	case head[0] == 0xFF && head[1] == 0xD8:
+		return peekJPEGOrientation(br)
+	case (head[0] == 'I' && head[1] == 'I' && head[2] == 0x2A && head[3] == 0x00),
+		(head[0] == 'M' && head[1] == 'M' && head[2] == 0x00 && head[3] == 0x2A):
+		buf, _ := br.Peek(exifPeekSize)
+		return tiffOrientation(buf)
+	}
+
+	return 0
+}
+
+// peekJPEGOrientation scans the JPEG marker segments buffered by br for an
+// APP1 "Exif" segment and returns the Orientation tag found in its embedded
+// TIFF structure, or 0 if there is none.
+func peekJPEGOrientation(br *bufio.Reader) int {
+	buf, _ := br.Peek(exifPeekSize)
+
+	pos := 2 // skip the SOI marker
+	for pos+4 <= len(buf) {
+		if buf[pos] != 0xFF {
+			return 0
+		}
+		marker := buf[pos+1]
+		if marker == 0xD8 || marker == 0xD9 || (marker >= 0xD0 && marker <= 0xD7) {
+			pos += 2
+			continue
+		}
+		if marker == 0xDA { // start of scan: no more metadata segments follow
+			return 0
+		}
+
+		length := int(binary.BigEndian.Uint16(buf[pos+2 : pos+4]))
+		if length < 2 || pos+2+length > len(buf) {
+			return 0
+		}
+		segment := buf[pos+4 : pos+2+length]
+
+		if marker == 0xE1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(segment[6:])
+		}
+
+		pos += 2 + length
+	}
+
+	return 0
+}
+
+// tiffOrientation parses a TIFF byte stream - or the TIFF structure embedded
+// in a JPEG Exif segment - and returns the Orientation tag from IFD0, or 0
+// if it isn't present.
+func tiffOrientation(buf []byte) int {
+	if len(buf) < 8 {
+		return 0
+	}
+
+	var order binary.ByteOrder
+	switch string(buf[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	ifdOffset := order.Uint32(buf[4:8])
+	if int(ifdOffset)+2 > len(buf) {
+		return 0
+	}
+
+	count := order.Uint16(buf[ifdOffset : ifdOffset+2])
+	pos := int(ifdOffset) + 2
+	for i := uint16(0); i < count; i++ {
+		if pos+12 > len(buf) {
+			return 0
+		}
+		entry := buf[pos : pos+12]
+		if order.Uint16(entry[0:2]) == exifOrientationTag {
+			return int(order.Uint16(entry[8:10]))
+		}
+		pos += 12
+	}
+
+	return 0
+}
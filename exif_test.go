@@ -0,0 +1,64 @@
+package imgconv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"image"
+	"testing"
+)
+
+// buildTIFFIFD0 builds a minimal little-endian TIFF header plus an IFD0
+// containing a single Orientation entry, enough for tiffOrientation to find.
+func buildTIFFIFD0(orientation uint16) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteString("II")
+	binary.Write(buf, binary.LittleEndian, uint16(42))
+	binary.Write(buf, binary.LittleEndian, uint32(8)) // IFD0 at offset 8
+
+	binary.Write(buf, binary.LittleEndian, uint16(1)) // one entry
+	binary.Write(buf, binary.LittleEndian, uint16(exifOrientationTag))
+	binary.Write(buf, binary.LittleEndian, uint16(3)) // type SHORT
+	binary.Write(buf, binary.LittleEndian, uint32(1)) // count
+	binary.Write(buf, binary.LittleEndian, orientation)
+	binary.Write(buf, binary.LittleEndian, uint16(0)) // padding to fill the 4-byte value slot
+	binary.Write(buf, binary.LittleEndian, uint32(0)) // next IFD offset
+
+	return buf.Bytes()
+}
+
+func TestTIFFOrientation(t *testing.T) {
+	if got := tiffOrientation(buildTIFFIFD0(6)); got != 6 {
+		t.Fatalf("got orientation %d, want 6", got)
+	}
+	if got := tiffOrientation([]byte("not a tiff")); got != 0 {
+		t.Fatalf("got orientation %d, want 0 for garbage input", got)
+	}
+}
+
+func TestPeekOrientationJPEG(t *testing.T) {
+	tiff := buildTIFFIFD0(8)
+	app1 := append([]byte("Exif\x00\x00"), tiff...)
+
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{0xFF, 0xD8})                                             // SOI
+	buf.Write([]byte{0xFF, 0xE1})                                             // APP1
+	binary.Write(buf, binary.BigEndian, uint16(len(app1)+2))                  // segment length, includes itself
+	buf.Write(app1)
+	buf.Write([]byte{0xFF, 0xDA, 0x00, 0x02}) // start of scan
+
+	if got := peekOrientation(bufio.NewReader(buf)); got != 8 {
+		t.Fatalf("got orientation %d, want 8", got)
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+
+	if out := applyOrientation(img, 6); out.Bounds().Dx() != 2 || out.Bounds().Dy() != 4 {
+		t.Fatalf("orientation 6: got bounds %v, want 2x4", out.Bounds())
+	}
+	if out := applyOrientation(img, 1); out.Bounds() != img.Bounds() {
+		t.Fatalf("orientation 1 should be a no-op, got bounds %v", out.Bounds())
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"image/jpeg"
 	"image/png"
 	"io"
+	"path/filepath"
 	"strings"
 
 	"github.com/sunshineplan/pdf"
@@ -27,6 +28,7 @@ const (
 	TIFF
 	BMP
 	PDF
+	WEBP
 )
 
 var formatExts = map[Format]string{
@@ -36,6 +38,7 @@ var formatExts = map[Format]string{
 	TIFF: "tif",
 	BMP:  "bmp",
 	PDF:  "pdf",
+	WEBP: "webp",
 }
 
 // TIFFCompression describes the type of compression used in Options.
@@ -71,6 +74,27 @@ func (c TIFFCompression) value() tiff.CompressionType {
 type FormatOption struct {
 	Format       Format
 	EncodeOption []EncodeOption
+	FileSystem   FileSystem
+	Transforms   []Transform
+	// AutoOrient makes Decode and Open apply EXIF auto-orientation, as
+	// DecodeWithOrientation and OpenWithOrientation do.
+	AutoOrient bool
+}
+
+// transform runs f.Transforms over img, in order.
+func (f FormatOption) transform(img image.Image) image.Image {
+	for _, t := range f.Transforms {
+		img = t(img)
+	}
+	return img
+}
+
+// WithFileSystem returns a copy of f that uses fs, instead of the
+// package-level FileSystem set with SetFileSystem, for file operations
+// performed by Save.
+func (f FormatOption) WithFileSystem(fs FileSystem) FormatOption {
+	f.FileSystem = fs
+	return f
 }
 
 type encodeConfig struct {
@@ -78,8 +102,10 @@ type encodeConfig struct {
 	gifNumColors        int
 	gifQuantizer        draw.Quantizer
 	gifDrawer           draw.Drawer
+	gifFrames           []GIFFrame
 	pngCompressionLevel png.CompressionLevel
 	tiffCompressionType TIFFCompression
+	webpLossless        bool
 }
 
 var defaultEncodeConfig = encodeConfig{
@@ -127,6 +153,26 @@ func GIFDrawer(drawer draw.Drawer) EncodeOption {
 	}
 }
 
+// GIFFrame describes the timing of a single frame of an animated GIF
+// produced by FormatOption.EncodeAll.
+type GIFFrame struct {
+	// Delay is the frame delay in 100ths of a second.
+	Delay int
+	// Disposal is the frame disposal method, one of the gif.Disposal* constants.
+	Disposal byte
+}
+
+// GIFFrameOptions returns an EncodeOption that sets the per-frame delay and
+// disposal method used by EncodeAll when it produces an animated GIF. frames
+// must have one entry per image passed to EncodeAll; if it is shorter, the
+// last entry is reused for the remaining frames. Without this option every
+// frame defaults to a zero delay and gif.DisposalNone.
+func GIFFrameOptions(frames []GIFFrame) EncodeOption {
+	return func(c *encodeConfig) {
+		c.gifFrames = frames
+	}
+}
+
 // PNGCompressionLevel returns an EncodeOption that sets the compression level
 // of the PNG-encoded image. Default is png.DefaultCompression.
 func PNGCompressionLevel(level png.CompressionLevel) EncodeOption {
@@ -143,8 +189,23 @@ func TIFFCompressionType(compressionType TIFFCompression) EncodeOption {
 	}
 }
 
+// WebPLossless returns an EncodeOption that selects lossless WebP encoding
+// instead of the default lossy mode, which uses Quality.
+func WebPLossless(lossless bool) EncodeOption {
+	return func(c *encodeConfig) {
+		c.webpLossless = lossless
+	}
+}
+
+// formatExtAliases holds the alternate spellings FormatFromExtension
+// accepts alongside the canonical extension in formatExts.
+var formatExtAliases = map[string]Format{
+	"jpeg": JPEG,
+	"tiff": TIFF,
+}
+
 // FormatFromExtension parses image format from filename extension:
-// "jpg" (or "jpeg"), "png", "gif", "tif" (or "tiff"), "bmp" and "pdf" are supported.
+// "jpg" (or "jpeg"), "png", "gif", "tif" (or "tiff"), "bmp", "pdf" and "webp" are supported.
 func FormatFromExtension(ext string) (Format, error) {
 	ext = strings.ToLower(ext)
 	for k, v := range formatExts {
@@ -152,10 +213,16 @@ func FormatFromExtension(ext string) (Format, error) {
 			return k, nil
 		}
 	}
+	if f, ok := formatExtAliases[ext]; ok {
+		return f, nil
+	}
 
 	return -1, errors.New("unsupported image format")
 }
 
+// StringOfFormat returns the lowercase file extension FormatFromExtension
+// and Save use for f, e.g. "jpg", or an error if f isn't one of the Format
+// constants. For f's display name (e.g. "JPEG"), see Format.String instead.
 func StringOfFormat(f Format) (string, error) {
 	str, ok := formatExts[f]
 	if !ok {
@@ -177,8 +244,10 @@ func setFormat(filename string, options ...EncodeOption) (fo FormatOption, err e
 	return
 }
 
-// Encode writes the image img to w in the specified format (JPEG, PNG, GIF, TIFF, BMP or PDF).
+// Encode writes the image img to w in the specified format (JPEG, PNG, GIF, TIFF, BMP, PDF or WebP).
 func (f *FormatOption) Encode(w io.Writer, img image.Image) error {
+	img = f.transform(img)
+
 	cfg := defaultEncodeConfig
 	for _, option := range f.EncodeOption {
 		option(&cfg)
@@ -215,7 +284,35 @@ func (f *FormatOption) Encode(w io.Writer, img image.Image) error {
 
 	case PDF:
 		return pdf.Encode(w, []image.Image{img}, &pdf.Options{Quality: cfg.Quality})
+
+	case WEBP:
+		return encodeWebP(w, img, cfg)
 	}
 
 	return errors.New("unsupported image format")
 }
+
+// Save writes img to the named file, deriving the format from filename's
+// extension via FormatFromExtension (overriding f.Format). Files are
+// created through f.FileSystem, or through the package-level FileSystem set
+// with SetFileSystem if f.FileSystem is nil.
+func (f FormatOption) Save(filename string, img image.Image) error {
+	format, err := FormatFromExtension(strings.TrimPrefix(filepath.Ext(filename), "."))
+	if err != nil {
+		return err
+	}
+	f.Format = format
+
+	fs := f.FileSystem
+	if fs == nil {
+		fs = fileSystem
+	}
+
+	file, err := fs.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return f.Encode(file, img)
+}
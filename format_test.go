@@ -18,6 +18,21 @@ func TestSetFormat(t *testing.T) {
 	}
 }
 
+func TestFormatFromExtensionAliases(t *testing.T) {
+	for _, ext := range []string{"jpeg", "JPEG", "tiff", "TIFF"} {
+		if _, err := FormatFromExtension(ext); err != nil {
+			t.Errorf("FormatFromExtension(%q): %v", ext, err)
+		}
+	}
+
+	if f, err := FormatFromExtension("jpeg"); err != nil || f != JPEG {
+		t.Errorf("FormatFromExtension(\"jpeg\") = (%v, %v), want (JPEG, nil)", f, err)
+	}
+	if f, err := FormatFromExtension("tiff"); err != nil || f != TIFF {
+		t.Errorf("FormatFromExtension(\"tiff\") = (%v, %v), want (TIFF, nil)", f, err)
+	}
+}
+
 func TestEncode(t *testing.T) {
 	testCase := []FormatOption{
 		{Format: JPEG, EncodeOption: []EncodeOption{Quality(75)}},
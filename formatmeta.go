@@ -0,0 +1,89 @@
+package imgconv
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+var formatNames = map[Format]string{
+	JPEG: "JPEG",
+	PNG:  "PNG",
+	GIF:  "GIF",
+	TIFF: "TIFF",
+	BMP:  "BMP",
+	PDF:  "PDF",
+	WEBP: "WebP",
+}
+
+var formatMIMETypes = map[Format]string{
+	JPEG: "image/jpeg",
+	PNG:  "image/png",
+	GIF:  "image/gif",
+	TIFF: "image/tiff",
+	BMP:  "image/bmp",
+	PDF:  "application/pdf",
+	WEBP: "image/webp",
+}
+
+// String returns the display name of f, e.g. "JPEG" or "WebP", or
+// "unsupported format" if f isn't one of the Format constants. For the
+// lowercase file extension used by FormatFromExtension and Save (e.g.
+// "jpg"), see StringOfFormat instead.
+func (f Format) String() string {
+	if name, ok := formatNames[f]; ok {
+		return name
+	}
+	return "unsupported format"
+}
+
+// MIMEType returns the MIME type of f, e.g. "image/jpeg", or
+// "application/octet-stream" if f isn't one of the Format constants.
+func (f Format) MIMEType() string {
+	if mime, ok := formatMIMETypes[f]; ok {
+		return mime
+	}
+	return "application/octet-stream"
+}
+
+// FormatFromMIME parses image format from a MIME type such as "image/jpeg".
+func FormatFromMIME(mime string) (Format, error) {
+	for format, m := range formatMIMETypes {
+		if m == mime {
+			return format, nil
+		}
+	}
+	return -1, errors.New("unsupported image format")
+}
+
+// DetectFormat sniffs the image format of r from its leading magic bytes
+// and returns it along with a reader that still yields the full stream
+// (including the bytes consumed while sniffing), so the caller can go on to
+// Decode it.
+func DetectFormat(r io.Reader) (Format, io.Reader, error) {
+	br := bufio.NewReaderSize(r, 16)
+	head, err := br.Peek(16)
+	if err != nil && err != io.EOF {
+		return -1, br, err
+	}
+
+	switch {
+	case len(head) >= 2 && head[0] == 0xFF && head[1] == 0xD8:
+		return JPEG, br, nil
+	case len(head) >= 8 && bytes.Equal(head[:8], []byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A}):
+		return PNG, br, nil
+	case len(head) >= 6 && bytes.Equal(head[:4], []byte("GIF8")):
+		return GIF, br, nil
+	case len(head) >= 4 && (bytes.Equal(head[:4], []byte("II*\x00")) || bytes.Equal(head[:4], []byte("MM\x00*"))):
+		return TIFF, br, nil
+	case len(head) >= 2 && bytes.Equal(head[:2], []byte("BM")):
+		return BMP, br, nil
+	case len(head) >= 5 && bytes.Equal(head[:5], []byte("%PDF-")):
+		return PDF, br, nil
+	case len(head) >= 12 && bytes.Equal(head[:4], []byte("RIFF")) && bytes.Equal(head[8:12], []byte("WEBP")):
+		return WEBP, br, nil
+	}
+
+	return -1, br, errors.New("unrecognized image format")
+}
@@ -0,0 +1,71 @@
+package imgconv
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestFormatString(t *testing.T) {
+	if got := JPEG.String(); got != "JPEG" {
+		t.Errorf("got %q, want JPEG", got)
+	}
+	if got := Format(-1).String(); got != "unsupported format" {
+		t.Errorf("got %q, want \"unsupported format\"", got)
+	}
+}
+
+func TestFormatMIMEType(t *testing.T) {
+	if mime := WEBP.MIMEType(); mime != "image/webp" {
+		t.Fatalf("got %q, want \"image/webp\"", mime)
+	}
+
+	if mime := Format(-1).MIMEType(); mime != "application/octet-stream" {
+		t.Fatalf("got %q, want \"application/octet-stream\"", mime)
+	}
+
+	format, err := FormatFromMIME("image/webp")
+	if err != nil || format != WEBP {
+		t.Fatalf("got (%v, %v), want (WEBP, nil)", format, err)
+	}
+	if _, err := FormatFromMIME("text/plain"); err == nil {
+		t.Fatal("FormatFromMIME of an unsupported MIME type expect an error")
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	testCase := []struct {
+		magic []byte
+		want  Format
+	}{
+		{[]byte{0xFF, 0xD8, 0xFF, 0xE0}, JPEG},
+		{[]byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A}, PNG},
+		{[]byte("GIF89a"), GIF},
+		{[]byte("II*\x00"), TIFF},
+		{[]byte("BM"), BMP},
+		{[]byte("%PDF-1.7"), PDF},
+		{append([]byte("RIFF\x00\x00\x00\x00"), []byte("WEBP")...), WEBP},
+	}
+
+	for _, tc := range testCase {
+		format, r, err := DetectFormat(bytes.NewReader(tc.magic))
+		if err != nil {
+			t.Fatalf("%v: %v", tc.want, err)
+		}
+		if format != tc.want {
+			t.Errorf("got %v, want %v", format, tc.want)
+		}
+
+		rest, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(rest, tc.magic) {
+			t.Error("DetectFormat's reader didn't replay the bytes it sniffed")
+		}
+	}
+
+	if _, _, err := DetectFormat(bytes.NewReader([]byte("not an image"))); err == nil {
+		t.Fatal("DetectFormat of unrecognized data expect an error")
+	}
+}
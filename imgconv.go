@@ -0,0 +1,60 @@
+package imgconv
+
+import (
+	"bufio"
+	"image"
+	"io"
+	"os"
+)
+
+// FileSystem is the set of file operations imgconv needs in order to open
+// and save images. Implementations can back Open and FormatOption.Save with
+// anything that looks like a filesystem: the local disk, an in-memory map
+// for unit tests, a tmpfs mount, or an object store such as S3.
+type FileSystem interface {
+	Create(name string) (io.WriteCloser, error)
+	Open(name string) (io.ReadCloser, error)
+	Stat(name string) (os.FileInfo, error)
+}
+
+// localFS implements FileSystem on top of the local disk using os.
+type localFS struct{}
+
+func (localFS) Create(name string) (io.WriteCloser, error) { return os.Create(name) }
+func (localFS) Open(name string) (io.ReadCloser, error)    { return os.Open(name) }
+func (localFS) Stat(name string) (os.FileInfo, error)      { return os.Stat(name) }
+
+// fileSystem is the package-level FileSystem used by Open, and by
+// FormatOption.Save when no per-call FileSystem has been set with
+// FormatOption.WithFileSystem. It defaults to the local disk.
+var fileSystem FileSystem = localFS{}
+
+// SetFileSystem sets the FileSystem used by Open and by FormatOption.Save
+// for calls that don't set their own FileSystem with WithFileSystem. It lets
+// imgconv be embedded in services that never touch the local disk.
+func SetFileSystem(fs FileSystem) {
+	fileSystem = fs
+}
+
+// Decode decodes an image that has been encoded in a registered format.
+func Decode(r io.Reader) (image.Image, error) {
+	img, _, err := image.Decode(r)
+	return img, err
+}
+
+// Open loads an image from filename using the package-level FileSystem (the
+// local disk unless changed with SetFileSystem).
+func Open(filename string) (image.Image, error) {
+	f, err := fileSystem.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if info, err := fileSystem.Stat(filename); err == nil && info.Size() > 0 {
+		r = bufio.NewReaderSize(f, int(info.Size()))
+	}
+
+	return Decode(r)
+}
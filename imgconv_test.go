@@ -0,0 +1,102 @@
+package imgconv
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"io"
+	"os"
+	"testing"
+)
+
+// memFS is a minimal in-memory FileSystem used to test that
+// FormatOption.Save honors the FileSystem it's given instead of always
+// touching the local disk.
+type memFS struct {
+	files map[string][]byte
+}
+
+type memReader struct{ *bytes.Reader }
+
+func (memReader) Close() error { return nil }
+
+type memWriter struct {
+	fs   *memFS
+	name string
+	*bytes.Buffer
+}
+
+func (w *memWriter) Close() error {
+	w.fs.files[w.name] = w.Bytes()
+	return nil
+}
+
+func (fs *memFS) Create(name string) (io.WriteCloser, error) {
+	return &memWriter{fs: fs, name: name, Buffer: new(bytes.Buffer)}, nil
+}
+
+func (fs *memFS) Open(name string) (io.ReadCloser, error) {
+	b, ok := fs.files[name]
+	if !ok {
+		return nil, errors.New("file does not exist")
+	}
+	return memReader{bytes.NewReader(b)}, nil
+}
+
+func (fs *memFS) Stat(name string) (os.FileInfo, error) {
+	return nil, errors.New("stat not supported")
+}
+
+func TestFormatOptionSaveWithFileSystem(t *testing.T) {
+	m0, err := Open("testdata/video-001.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := &memFS{files: make(map[string][]byte)}
+	fo := FormatOption{EncodeOption: []EncodeOption{Quality(75)}}.WithFileSystem(fs)
+
+	if err := fo.Save("out.jpg", m0); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fs.files["out.jpg"]; !ok {
+		t.Fatal("Save did not write through the provided FileSystem")
+	}
+
+	r, err := fs.Open("out.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	m1, err := Decode(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m0.Bounds() != m1.Bounds() {
+		t.Fatalf("bounds differ: %v and %v", m0.Bounds(), m1.Bounds())
+	}
+
+	if err := fo.Save("out.txt", m0); err == nil {
+		t.Fatal("save with unsupported extension expect an error")
+	}
+}
+
+func TestFormatOptionSave(t *testing.T) {
+	var img image.Image = &image.NRGBA{
+		Rect:   image.Rect(0, 0, 1, 1),
+		Stride: 1 * 4,
+		Pix:    []uint8{0xff, 0xff, 0xff, 0xff},
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{"out.png", "out.jpeg", "out.tiff"} {
+		filename := dir + "/" + name
+		if err := (FormatOption{}).Save(filename, img); err != nil {
+			t.Fatal(name, err)
+		}
+		if _, err := os.Stat(filename); err != nil {
+			t.Fatal(name, err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package imgconv
+
+import (
+	"bufio"
+	"image"
+	"io"
+
+	"github.com/disintegration/imaging"
+)
+
+// exifPeekSize bounds how much of the input orientation detection will
+// buffer via Peek. EXIF metadata always lives in the first segment of a
+// JPEG (or the whole file, for a bare TIFF), so this comfortably covers
+// real-world images while keeping decode a single pass over the stream.
+const exifPeekSize = 1 << 20
+
+// DecodeWithOrientation is like Decode, but additionally reads the EXIF
+// Orientation tag from JPEG and TIFF input and rotates/flips the result so
+// it's always in display orientation. Re-encoding the result is
+// automatically idempotent: none of imgconv's encoders copy EXIF metadata
+// from the source, so the output never carries an Orientation tag of its
+// own for a later decode to act on.
+func DecodeWithOrientation(r io.Reader) (image.Image, error) {
+	br := bufio.NewReaderSize(r, exifPeekSize)
+	orientation := peekOrientation(br)
+
+	img, err := Decode(br)
+	if err != nil {
+		return nil, err
+	}
+
+	return applyOrientation(img, orientation), nil
+}
+
+// OpenWithOrientation is like Open, but applies EXIF auto-orientation as
+// DecodeWithOrientation does.
+func OpenWithOrientation(filename string) (image.Image, error) {
+	f, err := fileSystem.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return DecodeWithOrientation(f)
+}
+
+// Decode decodes an image from r, applying EXIF auto-orientation if
+// f.AutoOrient is set.
+func (f FormatOption) Decode(r io.Reader) (image.Image, error) {
+	if f.AutoOrient {
+		return DecodeWithOrientation(r)
+	}
+	return Decode(r)
+}
+
+// Open loads an image from filename through f.FileSystem (or, if nil, the
+// package-level FileSystem set with SetFileSystem), applying EXIF
+// auto-orientation if f.AutoOrient is set.
+func (f FormatOption) Open(filename string) (image.Image, error) {
+	fs := f.FileSystem
+	if fs == nil {
+		fs = fileSystem
+	}
+
+	file, err := fs.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return f.Decode(file)
+}
+
+// applyOrientation rotates/flips img to undo the given EXIF orientation
+// value (1-8). Any other value, including 0 for "no Orientation tag found",
+// is a no-op.
+func applyOrientation(img image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return imaging.FlipH(img)
+	case 3:
+		return imaging.Rotate180(img)
+	case 4:
+		return imaging.FlipV(img)
+	case 5:
+		return imaging.Transpose(img)
+	case 6:
+		return imaging.Rotate270(img)
+	case 7:
+		return imaging.Transverse(img)
+	case 8:
+		return imaging.Rotate90(img)
+	}
+	return img
+}
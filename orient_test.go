@@ -0,0 +1,22 @@
+package imgconv
+
+import "testing"
+
+func TestFormatOptionAutoOrient(t *testing.T) {
+	m0, err := Open("testdata/video-001.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m1, err := (FormatOption{AutoOrient: true}).Open("testdata/video-001.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m0.Bounds() != m1.Bounds() {
+		t.Fatalf("bounds differ: %v and %v", m0.Bounds(), m1.Bounds())
+	}
+
+	if _, err := (FormatOption{}).Open("testdata/does-not-exist.png"); err == nil {
+		t.Fatal("open of a missing file expect an error")
+	}
+}
@@ -0,0 +1,396 @@
+package imgconv
+
+import (
+	"image"
+	"image/draw"
+	"math"
+	"runtime"
+	"sync"
+)
+
+// resampleKernel is a separable resampling filter: kernel is the filter
+// function and support is the radius (in source pixels, at scale 1:1)
+// outside of which it's zero.
+type resampleKernel struct {
+	support float64
+	kernel  func(float64) float64
+}
+
+var resampleKernels = map[ResampleFilter]resampleKernel{
+	NearestNeighbor: {
+		support: 0.5,
+		kernel: func(x float64) float64 {
+			if x >= -0.5 && x < 0.5 {
+				return 1
+			}
+			return 0
+		},
+	},
+	Linear: {
+		support: 1,
+		kernel: func(x float64) float64 {
+			x = math.Abs(x)
+			if x < 1 {
+				return 1 - x
+			}
+			return 0
+		},
+	},
+	CatmullRom: {
+		support: 2,
+		kernel: func(x float64) float64 {
+			x = math.Abs(x)
+			if x < 1 {
+				return ((1.5*x-2.5)*x)*x + 1
+			}
+			if x < 2 {
+				return (((-0.5*x+2.5)*x-4)*x + 2)
+			}
+			return 0
+		},
+	},
+	Lanczos: {
+		support: 3,
+		kernel: func(x float64) float64 {
+			if x == 0 {
+				return 1
+			}
+			if x > -3 && x < 3 {
+				return 3 * math.Sin(math.Pi*x) * math.Sin(math.Pi*x/3) / (math.Pi * math.Pi * x * x)
+			}
+			return 0
+		},
+	},
+}
+
+// weight is one term of a weighted sum over source pixels that produces a
+// single destination pixel.
+type weight struct {
+	index  int
+	weight float64
+}
+
+// precomputeWeights builds, for each of the dstSize output positions along
+// one axis, the list of source indices (0..srcSize-1) and normalized
+// weights a resampler should combine to produce it.
+func precomputeWeights(dstSize, srcSize int, k resampleKernel) [][]weight {
+	scale := float64(srcSize) / float64(dstSize)
+	filterScale := scale
+	if filterScale < 1 {
+		filterScale = 1
+	}
+	support := filterScale * k.support
+
+	weights := make([][]weight, dstSize)
+	for i := range weights {
+		center := (float64(i)+0.5)*scale - 0.5
+
+		start := int(math.Ceil(center - support))
+		if start < 0 {
+			start = 0
+		}
+		end := int(math.Floor(center + support))
+		if end > srcSize-1 {
+			end = srcSize - 1
+		}
+
+		var sum float64
+		var ws []weight
+		for j := start; j <= end; j++ {
+			w := k.kernel((float64(j) - center) / filterScale)
+			if w == 0 {
+				continue
+			}
+			sum += w
+			ws = append(ws, weight{index: j, weight: w})
+		}
+		if sum != 0 {
+			for i := range ws {
+				ws[i].weight /= sum
+			}
+		}
+		weights[i] = ws
+	}
+
+	return weights
+}
+
+// parallelRows calls fn(y) for every y in [0, n), spreading the work over
+// runtime.NumCPU() goroutines pulling row indices off a shared channel.
+func parallelRows(n int, fn func(y int)) {
+	workers := runtime.NumCPU()
+	if workers > n {
+		workers = n
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	rows := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for y := range rows {
+				fn(y)
+			}
+		}()
+	}
+	for y := 0; y < n; y++ {
+		rows <- y
+	}
+	close(rows)
+	wg.Wait()
+}
+
+// clampToUint8 rounds x to the nearest integer and clamps it to [0, 255].
+func clampToUint8(x float64) uint8 {
+	if x < 0 {
+		return 0
+	}
+	if x > 255 {
+		return 255
+	}
+	return uint8(x + 0.5)
+}
+
+// toNRGBA converts img to a zero-origin, tightly-packed *image.NRGBA (Stride
+// == Dx()*4), copying it if necessary. This normalizes away the case of an
+// *image.NRGBA produced by SubImage, whose Rect.Min isn't the origin and
+// whose Stride belongs to the larger backing image; the rest of this file
+// indexes pixels as if row 0 started at Pix offset 0, which only holds once
+// that's been normalized.
+func toNRGBA(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	if nrgba, ok := img.(*image.NRGBA); ok && nrgba.Rect.Min == image.Pt(0, 0) && nrgba.Stride == b.Dx()*4 {
+		return nrgba
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
+
+// resizeHorizontal resamples src to width columns, keeping its height,
+// using the per-output-column weight table produced by precomputeWeights.
+// Rows are processed in parallel since each is independent.
+func resizeHorizontal(src *image.NRGBA, width int, k resampleKernel) *image.NRGBA {
+	srcB := src.Bounds()
+	srcW, h := srcB.Dx(), srcB.Dy()
+	weights := precomputeWeights(width, srcW, k)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, h))
+
+	parallelRows(h, func(y int) {
+		srcRow := src.Pix[y*src.Stride : y*src.Stride+srcW*4]
+		dstRow := dst.Pix[y*dst.Stride : y*dst.Stride+width*4]
+		for x, ws := range weights {
+			var r, g, b, a float64
+			for _, w := range ws {
+				i := w.index * 4
+				r += float64(srcRow[i+0]) * w.weight
+				g += float64(srcRow[i+1]) * w.weight
+				b += float64(srcRow[i+2]) * w.weight
+				a += float64(srcRow[i+3]) * w.weight
+			}
+			j := x * 4
+			dstRow[j+0] = clampToUint8(r)
+			dstRow[j+1] = clampToUint8(g)
+			dstRow[j+2] = clampToUint8(b)
+			dstRow[j+3] = clampToUint8(a)
+		}
+	})
+
+	return dst
+}
+
+// resizeVertical resamples src to height rows, keeping its width, using the
+// per-output-row weight table produced by precomputeWeights. Output rows
+// are processed in parallel since each is independent.
+func resizeVertical(src *image.NRGBA, height int, k resampleKernel) *image.NRGBA {
+	srcB := src.Bounds()
+	w, srcH := srcB.Dx(), srcB.Dy()
+	weights := precomputeWeights(height, srcH, k)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, height))
+
+	parallelRows(height, func(y int) {
+		ws := weights[y]
+		dstRow := dst.Pix[y*dst.Stride : y*dst.Stride+w*4]
+		for x := 0; x < w; x++ {
+			var r, g, b, a float64
+			for _, wt := range ws {
+				i := wt.index*src.Stride + x*4
+				r += float64(src.Pix[i+0]) * wt.weight
+				g += float64(src.Pix[i+1]) * wt.weight
+				b += float64(src.Pix[i+2]) * wt.weight
+				a += float64(src.Pix[i+3]) * wt.weight
+			}
+			j := x * 4
+			dstRow[j+0] = clampToUint8(r)
+			dstRow[j+1] = clampToUint8(g)
+			dstRow[j+2] = clampToUint8(b)
+			dstRow[j+3] = clampToUint8(a)
+		}
+	})
+
+	return dst
+}
+
+// resize resamples src to width x height using the separable filter k,
+// running the horizontal and vertical passes independently so each only
+// ever does a 1-D convolution.
+func resize(img image.Image, width, height int, k resampleKernel) *image.NRGBA {
+	src := toNRGBA(img)
+	srcB := src.Bounds()
+	srcW, srcH := srcB.Dx(), srcB.Dy()
+
+	dst := src
+	if width != srcW {
+		dst = resizeHorizontal(dst, width, k)
+	}
+	if height != srcH {
+		dst = resizeVertical(dst, height, k)
+	}
+	return dst
+}
+
+// bilinearSample reverse-maps a fractional source coordinate (x, y) to an
+// interpolated pixel, reporting ok = false when the coordinate falls
+// entirely outside src (including its anti-aliasing margin), so the caller
+// can fill that destination pixel with its background color instead.
+func bilinearSample(src *image.NRGBA, x, y float64) (r, g, b, a uint8, ok bool) {
+	srcB := src.Bounds()
+	w, h := srcB.Dx(), srcB.Dy()
+	if x < -1 || y < -1 || x > float64(w) || y > float64(h) {
+		return 0, 0, 0, 0, false
+	}
+
+	x0 := int(math.Floor(x))
+	y0 := int(math.Floor(y))
+	fx := x - float64(x0)
+	fy := y - float64(y0)
+
+	get := func(px, py int) (float64, float64, float64, float64) {
+		if px < 0 || py < 0 || px >= w || py >= h {
+			return 0, 0, 0, 0
+		}
+		i := py*src.Stride + px*4
+		return float64(src.Pix[i]), float64(src.Pix[i+1]), float64(src.Pix[i+2]), float64(src.Pix[i+3])
+	}
+
+	r00, g00, b00, a00 := get(x0, y0)
+	r10, g10, b10, a10 := get(x0+1, y0)
+	r01, g01, b01, a01 := get(x0, y0+1)
+	r11, g11, b11, a11 := get(x0+1, y0+1)
+
+	lerp := func(v00, v10, v01, v11 float64) float64 {
+		top := v00*(1-fx) + v10*fx
+		bottom := v01*(1-fx) + v11*fx
+		return top*(1-fy) + bottom*fy
+	}
+
+	return clampToUint8(lerp(r00, r10, r01, r11)),
+		clampToUint8(lerp(g00, g10, g01, g11)),
+		clampToUint8(lerp(b00, b10, b01, b11)),
+		clampToUint8(lerp(a00, a10, a01, a11)),
+		true
+}
+
+// gaussianKernel1D returns a normalized 1-D Gaussian kernel for the given
+// standard deviation, wide enough to cover +/-3 sigma.
+func gaussianKernel1D(sigma float64) []float64 {
+	radius := int(math.Ceil(sigma * 3))
+	if radius < 1 {
+		radius = 1
+	}
+
+	k := make([]float64, radius*2+1)
+	var sum float64
+	for i := range k {
+		x := float64(i - radius)
+		v := math.Exp(-(x * x) / (2 * sigma * sigma))
+		k[i] = v
+		sum += v
+	}
+	for i := range k {
+		k[i] /= sum
+	}
+	return k
+}
+
+// convolveHorizontal applies the 1-D kernel k along each row of src,
+// clamping to the edge pixel past the image's bounds. Rows are processed in
+// parallel.
+func convolveHorizontal(src *image.NRGBA, k []float64) *image.NRGBA {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	radius := len(k) / 2
+	dst := image.NewNRGBA(b)
+
+	parallelRows(h, func(y int) {
+		srcRow := src.Pix[y*src.Stride : y*src.Stride+w*4]
+		dstRow := dst.Pix[y*dst.Stride : y*dst.Stride+w*4]
+		for x := 0; x < w; x++ {
+			var r, g, bl, a float64
+			for i, wt := range k {
+				sx := x + i - radius
+				if sx < 0 {
+					sx = 0
+				} else if sx >= w {
+					sx = w - 1
+				}
+				j := sx * 4
+				r += float64(srcRow[j+0]) * wt
+				g += float64(srcRow[j+1]) * wt
+				bl += float64(srcRow[j+2]) * wt
+				a += float64(srcRow[j+3]) * wt
+			}
+			j := x * 4
+			dstRow[j+0] = clampToUint8(r)
+			dstRow[j+1] = clampToUint8(g)
+			dstRow[j+2] = clampToUint8(bl)
+			dstRow[j+3] = clampToUint8(a)
+		}
+	})
+
+	return dst
+}
+
+// convolveVertical applies the 1-D kernel k along each column of src,
+// clamping to the edge pixel past the image's bounds. Output rows are
+// processed in parallel.
+func convolveVertical(src *image.NRGBA, k []float64) *image.NRGBA {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	radius := len(k) / 2
+	dst := image.NewNRGBA(b)
+
+	parallelRows(h, func(y int) {
+		dstRow := dst.Pix[y*dst.Stride : y*dst.Stride+w*4]
+		for x := 0; x < w; x++ {
+			var r, g, bl, a float64
+			for i, wt := range k {
+				sy := y + i - radius
+				if sy < 0 {
+					sy = 0
+				} else if sy >= h {
+					sy = h - 1
+				}
+				j := sy*src.Stride + x*4
+				r += float64(src.Pix[j+0]) * wt
+				g += float64(src.Pix[j+1]) * wt
+				bl += float64(src.Pix[j+2]) * wt
+				a += float64(src.Pix[j+3]) * wt
+			}
+			j := x * 4
+			dstRow[j+0] = clampToUint8(r)
+			dstRow[j+1] = clampToUint8(g)
+			dstRow[j+2] = clampToUint8(bl)
+			dstRow[j+3] = clampToUint8(a)
+		}
+	})
+
+	return dst
+}
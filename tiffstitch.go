@@ -0,0 +1,169 @@
+package imgconv
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// tiffFieldSize is the size in bytes of a single value of each TIFF field
+// type, indexed by the type's tag value (1-12) as defined by the TIFF 6.0
+// spec.
+var tiffFieldSize = map[uint16]int{
+	1: 1, 2: 1, 3: 2, 4: 4, 5: 8,
+	6: 1, 7: 1, 8: 2, 9: 4, 10: 8,
+	11: 4, 12: 8,
+}
+
+// tiffOffsetTags are the tags whose value (or, for a count greater than
+// one, every element of its value array) is itself a file offset, rather
+// than ordinary field data, and so must be rebased along with everything
+// else when a page moves. This covers every offset-bearing tag the
+// sunshineplan/tiff encoder can emit, including the strip form used by
+// every compression type and the JPEG-interchange form used by
+// TIFFCompressionType(TIFFJPEG).
+var tiffOffsetTags = map[uint16]bool{
+	273: true, // StripOffsets
+	288: true, // FreeOffsets
+	324: true, // TileOffsets
+	513: true, // JPEGInterchangeFormat
+}
+
+// stitchTIFF merges the single-page TIFFs in pages, each produced by
+// tiff.Encode, into one multi-page TIFF made of a chain of IFDs. It does so
+// by appending every page's content (past its 8-byte header) to a single
+// buffer and rewriting every offset the page contains - its IFD offset, any
+// directory entry whose value doesn't fit inline, and any StripOffsets or
+// TileOffsets entry, since those hold offsets even when stored inline - by
+// the distance the page's content moved.
+func stitchTIFF(pages [][]byte) ([]byte, error) {
+	if len(pages) == 0 {
+		return nil, errors.New("no page to stitch")
+	}
+	if len(pages) == 1 {
+		return pages[0], nil
+	}
+
+	order, err := tiffByteOrder(pages[0])
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 8)
+	copy(out, pages[0][:4])
+
+	var ifdOffsets []uint32
+	for i, page := range pages {
+		pageOrder, err := tiffByteOrder(page)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", i, err)
+		}
+		if pageOrder != order {
+			return nil, fmt.Errorf("page %d: mixed TIFF byte order not supported", i)
+		}
+
+		firstIFD := order.Uint32(page[4:8])
+		base := uint32(len(out)) - 8
+
+		body := make([]byte, len(page)-8)
+		copy(body, page[8:])
+		rewriteTIFFOffsets(order, body, firstIFD-8, base)
+
+		ifdOffsets = append(ifdOffsets, firstIFD+base)
+		out = append(out, body...)
+	}
+
+	order.PutUint32(out[4:8], ifdOffsets[0])
+
+	for i, ifdOffset := range ifdOffsets {
+		count := order.Uint16(out[ifdOffset : ifdOffset+2])
+		nextOffsetPos := ifdOffset + 2 + uint32(count)*12
+		var next uint32
+		if i+1 < len(ifdOffsets) {
+			next = ifdOffsets[i+1]
+		}
+		order.PutUint32(out[nextOffsetPos:nextOffsetPos+4], next)
+	}
+
+	return out, nil
+}
+
+func tiffByteOrder(page []byte) (binary.ByteOrder, error) {
+	if len(page) < 8 {
+		return nil, errors.New("not a valid TIFF: too short")
+	}
+	switch string(page[:2]) {
+	case "II":
+		return binary.LittleEndian, nil
+	case "MM":
+		return binary.BigEndian, nil
+	}
+	return nil, errors.New("not a valid TIFF: bad byte order marker")
+}
+
+// rewriteTIFFOffsets walks the single IFD at ifdOffset (relative to body) -
+// any IFD chained after it is not visited - and adds base to every offset
+// its entries hold: the
+// directory entry value for any field stored out-of-line (so the rebased
+// pointer keeps pointing at the field's own data, which moved along with
+// everything else), and, for tiffOffsetTags, every offset value the field
+// holds - inline or not, one or many - since those values point at other
+// data elsewhere in the page rather than being data themselves. The IFD's
+// own position when chaining to the next one is fixed up separately by the
+// caller, once every page's IFD offset within out is known.
+func rewriteTIFFOffsets(order binary.ByteOrder, body []byte, ifdOffset, base uint32) {
+	count := order.Uint16(body[ifdOffset : ifdOffset+2])
+	pos := ifdOffset + 2
+	for i := uint16(0); i < count; i++ {
+		entry := body[pos : pos+12]
+		tag := order.Uint16(entry[0:2])
+		typ := order.Uint16(entry[2:4])
+		num := order.Uint32(entry[4:8])
+
+		elemSize := tiffFieldSize[typ]
+		size := elemSize * int(num)
+
+		switch {
+		case size == 0:
+			// nothing to do
+
+		case tiffOffsetTags[tag] && size <= 4:
+			// One or more offsets packed inline in the entry's value slot.
+			rebaseTIFFOffsetArray(order, entry[8:8+size], elemSize, base)
+
+		case tiffOffsetTags[tag]:
+			// An out-of-line array of offsets: rebase the pointer to the
+			// array itself, then every offset stored inside it.
+			arrayOffset := order.Uint32(entry[8:12])
+			order.PutUint32(entry[8:12], arrayOffset+base)
+
+			start := int(arrayOffset) - 8
+			if start >= 0 && start+size <= len(body) {
+				rebaseTIFFOffsetArray(order, body[start:start+size], elemSize, base)
+			}
+
+		case size > 4:
+			// Out-of-line field data that isn't itself offsets: only the
+			// pointer to it moved.
+			offset := order.Uint32(entry[8:12])
+			order.PutUint32(entry[8:12], offset+base)
+		}
+
+		pos += 12
+	}
+}
+
+// rebaseTIFFOffsetArray adds base to every elemSize-wide offset packed into
+// data, in place.
+func rebaseTIFFOffsetArray(order binary.ByteOrder, data []byte, elemSize int, base uint32) {
+	for i := 0; i+elemSize <= len(data); i += elemSize {
+		switch elemSize {
+		case 2:
+			v := uint32(order.Uint16(data[i : i+2]))
+			order.PutUint16(data[i:i+2], uint16(v+base))
+		case 4:
+			v := order.Uint32(data[i : i+4])
+			order.PutUint32(data[i:i+4], v+base)
+		}
+	}
+}
@@ -0,0 +1,379 @@
+package imgconv
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// Transform adjusts an image before FormatOption.Encode or
+// FormatOption.EncodeAll writes it out, e.g. resizing, rotating or
+// cropping it. A FormatOption runs its Transforms in order, each taking the
+// previous one's output.
+type Transform func(image.Image) image.Image
+
+// ResampleFilter is a resampling filter used when resizing an image.
+type ResampleFilter int
+
+// Supported resampling filters, in increasing order of quality and cost.
+const (
+	NearestNeighbor ResampleFilter = iota
+	Linear
+	CatmullRom
+	Lanczos
+)
+
+// Anchor is a point of an image or rectangle that Fill crops around.
+type Anchor int
+
+// Supported anchors.
+const (
+	Center Anchor = iota
+	Top
+	TopLeft
+	TopRight
+	Left
+	Right
+	Bottom
+	BottomLeft
+	BottomRight
+)
+
+// anchorPt returns the top-left corner of a width x height box anchored
+// within an outerWidth x outerHeight area, per a.
+func anchorPt(a Anchor, width, height, outerWidth, outerHeight int) image.Point {
+	var x, y int
+	switch a {
+	case Top:
+		x, y = (outerWidth-width)/2, 0
+	case TopLeft:
+		x, y = 0, 0
+	case TopRight:
+		x, y = outerWidth-width, 0
+	case Left:
+		x, y = 0, (outerHeight-height)/2
+	case Right:
+		x, y = outerWidth-width, (outerHeight-height)/2
+	case Bottom:
+		x, y = (outerWidth-width)/2, outerHeight-height
+	case BottomLeft:
+		x, y = 0, outerHeight-height
+	case BottomRight:
+		x, y = outerWidth-width, outerHeight-height
+	default: // Center
+		x, y = (outerWidth-width)/2, (outerHeight-height)/2
+	}
+	return image.Pt(x, y)
+}
+
+// Resize returns a Transform that resizes an image to the given width and
+// height using filter, via separable convolution: a horizontal pass and a
+// vertical pass, each running its rows across a pool of runtime.NumCPU()
+// worker goroutines. If one of width or height is 0, it is computed
+// preserving the aspect ratio; if both are 0, img is returned unchanged.
+func Resize(width, height int, filter ResampleFilter) Transform {
+	return func(img image.Image) image.Image {
+		b := img.Bounds()
+		srcW, srcH := b.Dx(), b.Dy()
+		if srcW == 0 || srcH == 0 || (width <= 0 && height <= 0) {
+			return img
+		}
+
+		w, h := width, height
+		if w <= 0 {
+			w = int(math.Round(float64(srcW) * float64(h) / float64(srcH)))
+		}
+		if h <= 0 {
+			h = int(math.Round(float64(srcH) * float64(w) / float64(srcW)))
+		}
+		if w <= 0 {
+			w = 1
+		}
+		if h <= 0 {
+			h = 1
+		}
+
+		return resize(img, w, h, resampleKernels[filter])
+	}
+}
+
+// Fit returns a Transform that scales an image down to fit within a
+// width x height box, preserving its aspect ratio. It doesn't scale up
+// images smaller than the box.
+func Fit(width, height int) Transform {
+	return func(img image.Image) image.Image {
+		b := img.Bounds()
+		srcW, srcH := b.Dx(), b.Dy()
+		if srcW == 0 || srcH == 0 || width <= 0 || height <= 0 {
+			return img
+		}
+		if srcW <= width && srcH <= height {
+			return img
+		}
+
+		srcAspect := float64(srcW) / float64(srcH)
+		dstAspect := float64(width) / float64(height)
+
+		var w, h int
+		if srcAspect > dstAspect {
+			w = width
+			h = int(math.Round(float64(width) / srcAspect))
+		} else {
+			h = height
+			w = int(math.Round(float64(height) * srcAspect))
+		}
+		if w < 1 {
+			w = 1
+		}
+		if h < 1 {
+			h = 1
+		}
+
+		return resize(img, w, h, resampleKernels[Lanczos])
+	}
+}
+
+// Fill returns a Transform that resizes and crops an image to exactly fill
+// a width x height box, cropping around anchor.
+func Fill(width, height int, anchor Anchor) Transform {
+	return func(img image.Image) image.Image {
+		b := img.Bounds()
+		srcW, srcH := b.Dx(), b.Dy()
+		if srcW == 0 || srcH == 0 || width <= 0 || height <= 0 {
+			return img
+		}
+
+		srcAspect := float64(srcW) / float64(srcH)
+		dstAspect := float64(width) / float64(height)
+
+		var w, h int
+		if srcAspect > dstAspect {
+			h = height
+			w = int(math.Round(float64(height) * srcAspect))
+		} else {
+			w = width
+			h = int(math.Round(float64(width) / srcAspect))
+		}
+		if w < width {
+			w = width
+		}
+		if h < height {
+			h = height
+		}
+
+		resized := resize(img, w, h, resampleKernels[Lanczos])
+
+		pt := anchorPt(anchor, width, height, w, h)
+		rect := image.Rect(pt.X, pt.Y, pt.X+width, pt.Y+height)
+		return cropNRGBA(resized, rect)
+	}
+}
+
+// cropNRGBA returns the part of src within rect, translated to start at the
+// origin. rect is clamped to src's bounds.
+func cropNRGBA(src *image.NRGBA, rect image.Rectangle) *image.NRGBA {
+	rect = rect.Intersect(src.Bounds())
+	dst := image.NewNRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, rect.Min, draw.Src)
+	return dst
+}
+
+// Rotate returns a Transform that rotates an image by angle degrees
+// counterclockwise, filling the areas outside the original image with bg.
+// Each destination pixel is produced by reverse-mapping it into source
+// space and bilinearly sampling the four nearest source pixels.
+func Rotate(angle float64, bg color.Color) Transform {
+	return func(img image.Image) image.Image {
+		src := toNRGBA(img)
+		b := src.Bounds()
+		srcW, srcH := b.Dx(), b.Dy()
+
+		// Negated because y grows downward: a positive angle then turns the
+		// image counterclockwise as displayed.
+		rad := -angle * math.Pi / 180
+		sin, cos := math.Sin(rad), math.Cos(rad)
+
+		// Compute the bounding box of the rotated source rectangle.
+		corners := [4][2]float64{
+			{0, 0}, {float64(srcW), 0}, {0, float64(srcH)}, {float64(srcW), float64(srcH)},
+		}
+		srcCx, srcCy := float64(srcW)/2, float64(srcH)/2
+		minX, minY := math.Inf(1), math.Inf(1)
+		maxX, maxY := math.Inf(-1), math.Inf(-1)
+		for _, c := range corners {
+			x, y := c[0]-srcCx, c[1]-srcCy
+			rx := x*cos - y*sin
+			ry := x*sin + y*cos
+			minX, maxX = math.Min(minX, rx), math.Max(maxX, rx)
+			minY, maxY = math.Min(minY, ry), math.Max(maxY, ry)
+		}
+
+		dstW := int(math.Ceil(maxX - minX))
+		dstH := int(math.Ceil(maxY - minY))
+		if dstW < 1 {
+			dstW = 1
+		}
+		if dstH < 1 {
+			dstH = 1
+		}
+		dstCx, dstCy := float64(dstW)/2, float64(dstH)/2
+
+		r, g, bl, a := bg.RGBA()
+		bgColor := color.NRGBA{uint8(r >> 8), uint8(g >> 8), uint8(bl >> 8), uint8(a >> 8)}
+
+		dst := image.NewNRGBA(image.Rect(0, 0, dstW, dstH))
+
+		parallelRows(dstH, func(y int) {
+			for x := 0; x < dstW; x++ {
+				// Reverse-map (x, y) back into source space by rotating by -angle.
+				dx, dy := float64(x)-dstCx, float64(y)-dstCy
+				sx := dx*cos + dy*sin + srcCx
+				sy := -dx*sin + dy*cos + srcCy
+
+				var c color.NRGBA
+				if rr, gg, bb, aa, ok := bilinearSample(src, sx, sy); ok {
+					c = color.NRGBA{rr, gg, bb, aa}
+				} else {
+					c = bgColor
+				}
+
+				i := dst.PixOffset(x, y)
+				dst.Pix[i+0] = c.R
+				dst.Pix[i+1] = c.G
+				dst.Pix[i+2] = c.B
+				dst.Pix[i+3] = c.A
+			}
+		})
+
+		return dst
+	}
+}
+
+// Crop returns a Transform that crops an image to rect.
+func Crop(rect image.Rectangle) Transform {
+	return func(img image.Image) image.Image {
+		return cropNRGBA(toNRGBA(img), rect)
+	}
+}
+
+// GaussianBlur returns a Transform that blurs an image using a Gaussian
+// function of the given standard deviation, applied as two separable 1-D
+// convolution passes (horizontal then vertical).
+func GaussianBlur(sigma float64) Transform {
+	return func(img image.Image) image.Image {
+		if sigma <= 0 {
+			return img
+		}
+		k := gaussianKernel1D(sigma)
+		src := toNRGBA(img)
+		return convolveVertical(convolveHorizontal(src, k), k)
+	}
+}
+
+// Sharpen returns a Transform that sharpens an image using an unsharp mask:
+// it subtracts a Gaussian blur of the given standard deviation from the
+// original, amplifying the high-frequency detail that the blur removed.
+func Sharpen(sigma float64) Transform {
+	return func(img image.Image) image.Image {
+		if sigma <= 0 {
+			return img
+		}
+		src := toNRGBA(img)
+		k := gaussianKernel1D(sigma)
+		blurred := convolveVertical(convolveHorizontal(src, k), k)
+
+		b := src.Bounds()
+		dst := image.NewNRGBA(b)
+		for i := 0; i < len(src.Pix); i++ {
+			v := 2*int(src.Pix[i]) - int(blurred.Pix[i])
+			if v < 0 {
+				v = 0
+			} else if v > 255 {
+				v = 255
+			}
+			dst.Pix[i] = uint8(v)
+		}
+		return dst
+	}
+}
+
+// Grayscale returns a Transform that converts an image to grayscale, using
+// the standard luma weighting of the red, green and blue channels.
+func Grayscale() Transform {
+	return func(img image.Image) image.Image {
+		src := toNRGBA(img)
+		b := src.Bounds()
+		dst := image.NewNRGBA(b)
+		for i := 0; i < len(src.Pix); i += 4 {
+			r, g, bl := src.Pix[i], src.Pix[i+1], src.Pix[i+2]
+			y := clampToUint8(0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl))
+			dst.Pix[i+0] = y
+			dst.Pix[i+1] = y
+			dst.Pix[i+2] = y
+			dst.Pix[i+3] = src.Pix[i+3]
+		}
+		return dst
+	}
+}
+
+// AdjustBrightness returns a Transform that changes the brightness of an
+// image. percentage ranges from -100 (darken) to 100 (brighten).
+func AdjustBrightness(percentage float64) Transform {
+	shift := percentage / 100 * 255
+	return func(img image.Image) image.Image {
+		src := toNRGBA(img)
+		dst := image.NewNRGBA(src.Bounds())
+		for i := 0; i < len(src.Pix); i += 4 {
+			dst.Pix[i+0] = clampToUint8(float64(src.Pix[i+0]) + shift)
+			dst.Pix[i+1] = clampToUint8(float64(src.Pix[i+1]) + shift)
+			dst.Pix[i+2] = clampToUint8(float64(src.Pix[i+2]) + shift)
+			dst.Pix[i+3] = src.Pix[i+3]
+		}
+		return dst
+	}
+}
+
+// AdjustContrast returns a Transform that changes the contrast of an image.
+// percentage ranges from -100 (less contrast) to 100 (more contrast).
+func AdjustContrast(percentage float64) Transform {
+	percentage = math.Max(-100, math.Min(100, percentage))
+	factor := (100 + percentage) / 100
+	factor *= factor
+	return func(img image.Image) image.Image {
+		src := toNRGBA(img)
+		dst := image.NewNRGBA(src.Bounds())
+		adjust := func(v uint8) uint8 {
+			c := (float64(v)/255 - 0.5) * factor
+			return clampToUint8((c + 0.5) * 255)
+		}
+		for i := 0; i < len(src.Pix); i += 4 {
+			dst.Pix[i+0] = adjust(src.Pix[i+0])
+			dst.Pix[i+1] = adjust(src.Pix[i+1])
+			dst.Pix[i+2] = adjust(src.Pix[i+2])
+			dst.Pix[i+3] = src.Pix[i+3]
+		}
+		return dst
+	}
+}
+
+// AdjustGamma returns a Transform that applies gamma correction to an
+// image. gamma of 1 leaves the image unchanged; less than 1 darkens it,
+// greater than 1 lightens it.
+func AdjustGamma(gamma float64) Transform {
+	var lut [256]uint8
+	for i := range lut {
+		lut[i] = clampToUint8(math.Pow(float64(i)/255, 1/gamma) * 255)
+	}
+	return func(img image.Image) image.Image {
+		src := toNRGBA(img)
+		dst := image.NewNRGBA(src.Bounds())
+		for i := 0; i < len(src.Pix); i += 4 {
+			dst.Pix[i+0] = lut[src.Pix[i+0]]
+			dst.Pix[i+1] = lut[src.Pix[i+1]]
+			dst.Pix[i+2] = lut[src.Pix[i+2]]
+			dst.Pix[i+3] = src.Pix[i+3]
+		}
+		return dst
+	}
+}
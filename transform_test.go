@@ -0,0 +1,58 @@
+package imgconv
+
+import (
+	"bytes"
+	"image"
+	"testing"
+)
+
+func TestFormatOptionTransforms(t *testing.T) {
+	m0, err := Open("testdata/video-001.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fo := &FormatOption{
+		Format: PNG,
+		Transforms: []Transform{
+			Resize(100, 0, Lanczos),
+			Grayscale(),
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := fo.Encode(&buf, m0); err != nil {
+		t.Fatal(err)
+	}
+
+	m1, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m1.Bounds().Dx() != 100 {
+		t.Fatalf("got width %d, want 100", m1.Bounds().Dx())
+	}
+}
+
+func TestCropTransform(t *testing.T) {
+	m0, err := Open("testdata/video-001.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rect := image.Rect(0, 0, 10, 10)
+	fo := &FormatOption{Format: PNG, Transforms: []Transform{Crop(rect)}}
+
+	var buf bytes.Buffer
+	if err := fo.Encode(&buf, m0); err != nil {
+		t.Fatal(err)
+	}
+
+	m1, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m1.Bounds().Dx() != 10 || m1.Bounds().Dy() != 10 {
+		t.Fatalf("got bounds %v, want 10x10", m1.Bounds())
+	}
+}
@@ -0,0 +1,21 @@
+//go:build cgo
+// +build cgo
+
+package imgconv
+
+import (
+	"image"
+	"io"
+
+	"github.com/chai2010/webp"
+)
+
+// encodeWebP encodes img as WebP using chai2010/webp, which wraps Google's
+// libwebp via cgo. Build without cgo to get a clear error instead, since
+// there's no pure-Go WebP encoder in this module's dependency set.
+func encodeWebP(w io.Writer, img image.Image, cfg encodeConfig) error {
+	return webp.Encode(w, img, &webp.Options{
+		Lossless: cfg.webpLossless,
+		Quality:  float32(cfg.Quality),
+	})
+}
@@ -0,0 +1,18 @@
+//go:build !cgo
+// +build !cgo
+
+package imgconv
+
+import (
+	"errors"
+	"image"
+	"io"
+)
+
+// encodeWebP is a stub used when building without cgo: this module has no
+// pure-Go WebP encoder, only the cgo-backed chai2010/webp one in
+// webp_cgo.go. Decoding WebP still works in every build, since
+// golang.org/x/image/webp is pure Go.
+func encodeWebP(io.Writer, image.Image, encodeConfig) error {
+	return errors.New("webp encoding requires building with cgo enabled")
+}